@@ -0,0 +1,113 @@
+package blobfs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// AuthProvider resolves the storage account and pipeline credential a Client
+// should use, independent of how that credential was obtained. This is what
+// lets Client support shared key, SAS, and Azure AD auth interchangeably.
+type AuthProvider interface {
+	// Account returns the storage account name to connect to.
+	Account() string
+	// Credential builds the azblob.Credential to use for requests.
+	Credential(ctx context.Context) (azblob.Credential, error)
+	// SASQuery returns the raw SAS query string to append to every request,
+	// or "" if the provider doesn't use one.
+	SASQuery() string
+}
+
+// SharedKeyAuth authenticates with a storage account name and key.
+type SharedKeyAuth struct {
+	AccountName string
+	AccountKey  string
+}
+
+func (a SharedKeyAuth) Account() string { return a.AccountName }
+
+func (a SharedKeyAuth) Credential(ctx context.Context) (azblob.Credential, error) {
+	cred, err := azblob.NewSharedKeyCredential(a.AccountName, a.AccountKey)
+	if err != nil {
+		return nil, fmt.Errorf("blobfs: invalid shared key for %s: %w", a.AccountName, err)
+	}
+	return cred, nil
+}
+
+func (a SharedKeyAuth) SASQuery() string { return "" }
+
+// SASAuth authenticates anonymously using a pre-signed SAS query string.
+type SASAuth struct {
+	AccountName string
+	Query       string // raw SAS query string, without the leading "?"
+}
+
+func (a SASAuth) Account() string { return a.AccountName }
+
+func (a SASAuth) Credential(ctx context.Context) (azblob.Credential, error) {
+	return azblob.NewAnonymousCredential(), nil
+}
+
+func (a SASAuth) SASQuery() string { return a.Query }
+
+// storageResourceScope is the OAuth scope Azure Storage expects bearer
+// tokens to be issued for.
+const storageResourceScope = "https://storage.azure.com/.default"
+
+// AADAuth authenticates with Azure AD using one of azidentity's token
+// credential flows, refreshing bearer tokens automatically. Set exactly one
+// of ClientSecret-with-TenantID/ClientID, UseManagedIdentity, or
+// UseAzureCLI.
+type AADAuth struct {
+	AccountName string
+
+	TenantID           string
+	ClientID           string
+	ClientSecret       string
+	UseManagedIdentity bool
+	UseAzureCLI        bool
+}
+
+func (a AADAuth) Account() string { return a.AccountName }
+
+func (a AADAuth) Credential(ctx context.Context) (azblob.Credential, error) {
+	tokenCred, err := a.azidentityCredential()
+	if err != nil {
+		return nil, err
+	}
+
+	refresh := func(c azblob.TokenCredential) time.Duration {
+		token, err := tokenCred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{storageResourceScope}})
+		if err != nil {
+			// Retry soon rather than giving up for good; the pipeline will
+			// surface the auth failure from the next request if this keeps
+			// failing.
+			return time.Second
+		}
+		c.SetToken(token.Token)
+		return time.Until(token.ExpiresOn) - time.Minute
+	}
+
+	return azblob.NewTokenCredential("", refresh), nil
+}
+
+func (a AADAuth) azidentityCredential() (azcore.TokenCredential, error) {
+	switch {
+	case a.UseManagedIdentity:
+		return azidentity.NewManagedIdentityCredential(nil)
+	case a.UseAzureCLI:
+		return azidentity.NewAzureCLICredential(nil)
+	case a.TenantID != "" && a.ClientID != "" && a.ClientSecret != "":
+		return azidentity.NewClientSecretCredential(a.TenantID, a.ClientID, a.ClientSecret, nil)
+	default:
+		return nil, fmt.Errorf("blobfs: aad auth needs tenant_id/client_id/client_secret, managed identity, or an Azure CLI login")
+	}
+}
+
+func (a AADAuth) SASQuery() string { return "" }
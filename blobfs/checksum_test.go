@@ -0,0 +1,59 @@
+package blobfs
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+func TestLookupMetadataCaseInsensitive(t *testing.T) {
+	meta := azblob.Metadata{"Blobfscrc64": "abc123"}
+	got, ok := lookupMetadata(meta, crc64MetadataKey)
+	if !ok || got != "abc123" {
+		t.Fatalf("lookupMetadata() = (%q, %v), want (\"abc123\", true)", got, ok)
+	}
+}
+
+func TestLookupMetadataMissing(t *testing.T) {
+	meta := azblob.Metadata{"unrelated": "x"}
+	if _, ok := lookupMetadata(meta, crc64MetadataKey); ok {
+		t.Fatal("lookupMetadata() found a key that isn't there")
+	}
+}
+
+func TestChecksumFileRoundTrip(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "checksum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString("hello world"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	sum1, err := checksumFile(f, ChecksumMD5)
+	if err != nil {
+		t.Fatalf("checksumFile: %v", err)
+	}
+	// checksumFile must leave the read position at the start so the caller
+	// can immediately reuse f for the actual upload.
+	sum2, err := checksumFile(f, ChecksumMD5)
+	if err != nil {
+		t.Fatalf("checksumFile (second pass): %v", err)
+	}
+	if string(sum1) != string(sum2) {
+		t.Fatal("checksumFile() did not reset the file's read position")
+	}
+
+	crcSum, err := checksumFile(f, ChecksumCRC64)
+	if err != nil {
+		t.Fatalf("checksumFile (crc64): %v", err)
+	}
+	if string(crcSum) == string(sum1) {
+		t.Fatal("ChecksumMD5 and ChecksumCRC64 produced the same digest")
+	}
+}
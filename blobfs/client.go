@@ -0,0 +1,285 @@
+// Package blobfs wraps the Azure Storage Blob SDK with a small,
+// filesystem-flavoured API: blobs are addressed by abs://<account>/<container>/<path>
+// URIs, and callers move data around with Upload/Download/Copy instead of
+// juggling ContainerURLs and BlockBlobURLs directly.
+package blobfs
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// Client is a handle to a single Azure Storage account, ready to move blobs
+// to and from the local filesystem.
+type Client struct {
+	account  string
+	pipeline pipeline.Pipeline
+	sasQuery string // raw SAS query string, set when authenticated via SAS URL
+}
+
+// NewClient builds a Client for the given account using an already-resolved
+// credential. sasQuery is the raw SAS query string to append to every
+// request; pass "" when cred is a shared key or AAD token credential.
+func NewClient(account string, cred azblob.Credential, sasQuery string) *Client {
+	return &Client{
+		account:  account,
+		pipeline: azblob.NewPipeline(cred, azblob.PipelineOptions{}),
+		sasQuery: sasQuery,
+	}
+}
+
+// NewClientWithAuth builds a Client from an AuthProvider, e.g. one returned
+// by ResolveAuthProvider.
+func NewClientWithAuth(ctx context.Context, provider AuthProvider) (*Client, error) {
+	cred, err := provider.Credential(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(provider.Account(), cred, provider.SASQuery()), nil
+}
+
+// NewClientFromEnvironment builds a Client using ResolveAuthProvider.
+func NewClientFromEnvironment() (*Client, error) {
+	provider, err := ResolveAuthProvider()
+	if err != nil {
+		return nil, err
+	}
+	return NewClientWithAuth(context.Background(), provider)
+}
+
+func (c *Client) serviceURL() azblob.ServiceURL {
+	raw := fmt.Sprintf("https://%s.blob.core.windows.net/", c.account)
+	u, err := url.Parse(raw)
+	if err != nil {
+		// c.account only ever comes from ResolveAuthProvider/NewClient callers,
+		// so a malformed host here is a programmer error, not a runtime one.
+		panic(fmt.Sprintf("blobfs: invalid account name %q", c.account))
+	}
+	if c.sasQuery != "" {
+		u.RawQuery = c.sasQuery
+	}
+	return azblob.NewServiceURL(*u, c.pipeline)
+}
+
+func (c *Client) containerURL(container string) azblob.ContainerURL {
+	return c.serviceURL().NewContainerURL(container)
+}
+
+func (c *Client) blockBlobURL(container, blob string) azblob.BlockBlobURL {
+	return c.containerURL(container).NewBlockBlobURL(blob)
+}
+
+// checkAccount rejects a Location that names a storage account other than
+// the one this Client is connected to. ParseURI captures Location.Account
+// from an abs:// URI, but every request this Client issues goes to its own
+// single account, so a mismatch here would otherwise run silently against
+// the wrong account instead of the one the caller named.
+func (c *Client) checkAccount(loc Location) error {
+	if !loc.Local && loc.Account != "" && loc.Account != c.account {
+		return fmt.Errorf("blobfs: %s addresses account %q but this client is connected to %q; cross-account operations are not supported", loc, loc.Account, c.account)
+	}
+	return nil
+}
+
+// ListContainers returns the names of every container in the account.
+func (c *Client) ListContainers(ctx context.Context) ([]string, error) {
+	serviceURL := c.serviceURL()
+
+	var names []string
+	for marker := (azblob.Marker{}); marker.NotDone(); {
+		resp, err := serviceURL.ListContainersSegment(ctx, marker, azblob.ListContainersSegmentOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("blobfs: list containers: %w", err)
+		}
+		marker = resp.NextMarker
+		for _, item := range resp.ContainerItems {
+			names = append(names, item.Name)
+		}
+	}
+	return names, nil
+}
+
+// CreateContainer creates the named container, treating "already exists" as
+// success so the quick-start demo can be re-run against the same account.
+func (c *Client) CreateContainer(ctx context.Context, container string) error {
+	_, err := c.containerURL(container).Create(ctx, azblob.Metadata{}, azblob.PublicAccessNone)
+	if err != nil {
+		if serr, ok := err.(azblob.StorageError); ok && serr.ServiceCode() == azblob.ServiceCodeContainerAlreadyExists {
+			return nil
+		}
+		return fmt.Errorf("blobfs: create container %s: %w", container, err)
+	}
+	return nil
+}
+
+// DeleteContainer deletes the named container and everything in it.
+func (c *Client) DeleteContainer(ctx context.Context, container string) error {
+	if _, err := c.containerURL(container).Delete(ctx, azblob.ContainerAccessConditions{}); err != nil {
+		return fmt.Errorf("blobfs: delete container %s: %w", container, err)
+	}
+	return nil
+}
+
+// Upload uploads the file at localPath to the blob described by dst.
+func (c *Client) Upload(ctx context.Context, localPath string, dst Location) error {
+	return c.UploadWithOptions(ctx, localPath, dst, UploadOptions{})
+}
+
+// uploadFile is the shared implementation behind Upload and
+// UploadWithOptions: it opens localPath, optionally hashes it under
+// opts.Checksum, and uploads it to dst with the resulting headers/metadata.
+func (c *Client) uploadFile(ctx context.Context, localPath string, dst Location, opts UploadOptions) error {
+	if err := c.checkAccount(dst); err != nil {
+		return err
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("blobfs: open %s: %w", localPath, err)
+	}
+	defer file.Close()
+
+	uploadOpts := azblob.UploadToBlockBlobOptions{
+		BlockSize:   4 * 1024 * 1024,
+		Parallelism: 16,
+	}
+	if opts.Checksum != ChecksumNone {
+		sum, err := checksumFile(file, opts.Checksum)
+		if err != nil {
+			return fmt.Errorf("blobfs: checksum %s: %w", localPath, err)
+		}
+		switch opts.Checksum {
+		case ChecksumMD5:
+			uploadOpts.BlobHTTPHeaders.ContentMD5 = sum
+		case ChecksumCRC64:
+			uploadOpts.Metadata = azblob.Metadata{crc64MetadataKey: base64.StdEncoding.EncodeToString(sum)}
+		}
+	}
+
+	blobURL := c.blockBlobURL(dst.Container, dst.Blob)
+	if _, err := azblob.UploadFileToBlockBlob(ctx, file, blobURL, uploadOpts); err != nil {
+		return fmt.Errorf("blobfs: upload %s to %s: %w", localPath, dst, err)
+	}
+	return nil
+}
+
+// Download downloads the blob described by src to localPath.
+func (c *Client) Download(ctx context.Context, src Location, localPath string) error {
+	if err := c.checkAccount(src); err != nil {
+		return err
+	}
+
+	blobURL := c.blockBlobURL(src.Container, src.Blob)
+	resp, err := blobURL.Download(ctx, 0, azblob.CountToEnd, azblob.BlobAccessConditions{}, false)
+	if err != nil {
+		return fmt.Errorf("blobfs: download %s: %w", src, err)
+	}
+
+	out, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("blobfs: create %s: %w", localPath, err)
+	}
+	defer out.Close()
+
+	body := resp.Body(azblob.RetryReaderOptions{MaxRetryRequests: 20})
+	defer body.Close()
+
+	if _, err := io.Copy(out, body); err != nil {
+		return fmt.Errorf("blobfs: download %s: %w", src, err)
+	}
+	return nil
+}
+
+// List returns the names of the blobs in container whose names start with
+// prefix.
+func (c *Client) List(ctx context.Context, container, prefix string) ([]string, error) {
+	containerURL := c.containerURL(container)
+
+	var names []string
+	for marker := (azblob.Marker{}); marker.NotDone(); {
+		resp, err := containerURL.ListBlobsFlatSegment(ctx, marker, azblob.ListBlobsSegmentOptions{Prefix: prefix})
+		if err != nil {
+			return nil, fmt.Errorf("blobfs: list %s/%s*: %w", container, prefix, err)
+		}
+		marker = resp.NextMarker
+		for _, item := range resp.Segment.BlobItems {
+			names = append(names, item.Name)
+		}
+	}
+	return names, nil
+}
+
+// Delete deletes the blob described by loc.
+func (c *Client) Delete(ctx context.Context, loc Location) error {
+	if err := c.checkAccount(loc); err != nil {
+		return err
+	}
+
+	blobURL := c.blockBlobURL(loc.Container, loc.Blob)
+	if _, err := blobURL.Delete(ctx, azblob.DeleteSnapshotsOptionNone, azblob.BlobAccessConditions{}); err != nil {
+		return fmt.Errorf("blobfs: delete %s: %w", loc, err)
+	}
+	return nil
+}
+
+// Stat returns the properties of the blob described by loc.
+func (c *Client) Stat(ctx context.Context, loc Location) (*azblob.BlobGetPropertiesResponse, error) {
+	if err := c.checkAccount(loc); err != nil {
+		return nil, err
+	}
+
+	blobURL := c.blockBlobURL(loc.Container, loc.Blob)
+	resp, err := blobURL.GetProperties(ctx, azblob.BlobAccessConditions{})
+	if err != nil {
+		return nil, fmt.Errorf("blobfs: stat %s: %w", loc, err)
+	}
+	return resp, nil
+}
+
+// Copy copies srcURI to dstURI, where either side may be a local path or an
+// abs://<account>/<container>/<path> blob URI.
+func (c *Client) Copy(ctx context.Context, srcURI, dstURI string) error {
+	src, err := ParseURI(srcURI)
+	if err != nil {
+		return err
+	}
+	dst, err := ParseURI(dstURI)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case src.Local && !dst.Local:
+		return c.Upload(ctx, src.Path, dst)
+	case !src.Local && dst.Local:
+		return c.Download(ctx, src, dst.Path)
+	case !src.Local && !dst.Local:
+		return c.copyRemote(ctx, src, dst)
+	default:
+		return fmt.Errorf("blobfs: copying between two local paths is not supported")
+	}
+}
+
+func (c *Client) copyRemote(ctx context.Context, src, dst Location) error {
+	if err := c.checkAccount(src); err != nil {
+		return err
+	}
+	if err := c.checkAccount(dst); err != nil {
+		return err
+	}
+
+	srcURL := c.blockBlobURL(src.Container, src.Blob).URL()
+	dstBlobURL := c.blockBlobURL(dst.Container, dst.Blob)
+	_, err := dstBlobURL.StartCopyFromURL(ctx, srcURL, azblob.Metadata{}, azblob.ModifiedAccessConditions{}, azblob.BlobAccessConditions{}, azblob.AccessTierNone, nil)
+	if err != nil {
+		return fmt.Errorf("blobfs: copy %s to %s: %w", src, dst, err)
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package blobfs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// CopyTreeOptions controls CopyTree's traversal, filtering, and concurrency.
+type CopyTreeOptions struct {
+	// Include, if non-empty, keeps only entries whose relative path matches
+	// at least one of these path.Match-style glob patterns.
+	Include []string
+	// Exclude drops any entry whose relative path matches one of these
+	// path.Match-style glob patterns, even if it matched Include.
+	Exclude []string
+	// Concurrency bounds how many copies run at once. Defaults to 1.
+	Concurrency int
+	// DryRun, if true, prints the planned copies instead of performing them.
+	DryRun bool
+	// Progress, if set, is called after each entry completes (or would have,
+	// under DryRun).
+	Progress func(done, total int64, name string)
+}
+
+// CopyTree mirrors everything under srcURI to dstURI, where either side may
+// be a local directory or a blob prefix addressed by
+// abs://<account>/<container>/<prefix>.
+func (c *Client) CopyTree(ctx context.Context, srcURI, dstURI string, opts CopyTreeOptions) error {
+	src, err := ParseURI(srcURI)
+	if err != nil {
+		return err
+	}
+	dst, err := ParseURI(dstURI)
+	if err != nil {
+		return err
+	}
+
+	rels, err := c.listTreeRelative(ctx, src)
+	if err != nil {
+		return fmt.Errorf("blobfs: CopyTree: list %s: %w", src, err)
+	}
+
+	planned, err := filterEntries(rels, opts)
+	if err != nil {
+		return fmt.Errorf("blobfs: CopyTree: %w", err)
+	}
+
+	total := int64(len(planned))
+	if opts.DryRun {
+		for i, rel := range planned {
+			fmt.Printf("would copy %s -> %s\n", joinLocation(src, rel), joinLocation(dst, rel))
+			if opts.Progress != nil {
+				opts.Progress(int64(i+1), total, rel)
+			}
+		}
+		return nil
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	sem := make(chan struct{}, concurrency)
+	errs := make(chan error, len(planned))
+	var wg sync.WaitGroup
+	var done int64
+
+	for _, rel := range planned {
+		rel := rel
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			target := joinLocation(dst, rel)
+			var err error
+			if target.Local {
+				err = os.MkdirAll(filepath.Dir(target.Path), 0755)
+			}
+			if err == nil {
+				err = c.Copy(ctx, joinLocation(src, rel).String(), target.String())
+			}
+			n := atomic.AddInt64(&done, 1)
+			if opts.Progress != nil {
+				opts.Progress(n, total, rel)
+			}
+			if err != nil {
+				errs <- fmt.Errorf("copy %s: %w", rel, err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		return err
+	}
+	return nil
+}
+
+// listTreeRelative lists the entries under loc, relative to loc itself.
+func (c *Client) listTreeRelative(ctx context.Context, loc Location) ([]string, error) {
+	if loc.Local {
+		return listLocalTree(loc.Path)
+	}
+
+	names, err := c.listRemoteTree(ctx, loc.Container, loc.Blob)
+	if err != nil {
+		return nil, err
+	}
+	return stripPrefix(names, loc.Blob), nil
+}
+
+func listLocalTree(root string) ([]string, error) {
+	var rels []string
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rels = append(rels, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rels, nil
+}
+
+// listRemoteTree walks the container below prefix using
+// ListBlobsHierarchySegment with "/" as the delimiter, recursing into each
+// virtual directory it finds.
+func (c *Client) listRemoteTree(ctx context.Context, container, prefix string) ([]string, error) {
+	containerURL := c.containerURL(container)
+
+	var names []string
+	var walk func(prefix string) error
+	walk = func(prefix string) error {
+		for marker := (azblob.Marker{}); marker.NotDone(); {
+			resp, err := containerURL.ListBlobsHierarchySegment(ctx, marker, "/", azblob.ListBlobsSegmentOptions{Prefix: prefix})
+			if err != nil {
+				return fmt.Errorf("blobfs: list %s/%s*: %w", container, prefix, err)
+			}
+			marker = resp.NextMarker
+
+			for _, item := range resp.Segment.BlobItems {
+				names = append(names, item.Name)
+			}
+			for _, sub := range resp.Segment.BlobPrefixes {
+				if err := walk(sub.Name); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := walk(prefix); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+func stripPrefix(names []string, prefix string) []string {
+	prefix = strings.TrimSuffix(prefix, "/")
+	rels := make([]string, 0, len(names))
+	for _, name := range names {
+		rel := strings.TrimPrefix(name, prefix)
+		rel = strings.TrimPrefix(rel, "/")
+		rels = append(rels, rel)
+	}
+	return rels
+}
+
+func filterEntries(rels []string, opts CopyTreeOptions) ([]string, error) {
+	var kept []string
+	for _, rel := range rels {
+		ok, err := matchesFilters(rel, opts)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			kept = append(kept, rel)
+		}
+	}
+	return kept, nil
+}
+
+func matchesFilters(name string, opts CopyTreeOptions) (bool, error) {
+	if len(opts.Include) > 0 {
+		included := false
+		for _, pattern := range opts.Include {
+			ok, err := path.Match(pattern, name)
+			if err != nil {
+				return false, fmt.Errorf("bad include pattern %q: %w", pattern, err)
+			}
+			if ok {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false, nil
+		}
+	}
+
+	for _, pattern := range opts.Exclude {
+		ok, err := path.Match(pattern, name)
+		if err != nil {
+			return false, fmt.Errorf("bad exclude pattern %q: %w", pattern, err)
+		}
+		if ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// joinLocation appends rel to base, keeping base's kind (local path or blob).
+func joinLocation(base Location, rel string) Location {
+	if base.Local {
+		return Location{Local: true, Path: filepath.Join(base.Path, filepath.FromSlash(rel))}
+	}
+	return Location{Account: base.Account, Container: base.Container, Blob: path.Join(base.Blob, rel)}
+}
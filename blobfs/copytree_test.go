@@ -0,0 +1,61 @@
+package blobfs
+
+import "testing"
+
+func TestFilterEntriesNoFilters(t *testing.T) {
+	rels := []string{"a.txt", "dir/b.txt"}
+	got, err := filterEntries(rels, CopyTreeOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != len(rels) {
+		t.Fatalf("filterEntries() = %v, want everything kept", got)
+	}
+}
+
+func TestFilterEntriesInclude(t *testing.T) {
+	rels := []string{"a.txt", "b.log", "dir/c.txt"}
+	got, err := filterEntries(rels, CopyTreeOptions{Include: []string{"*.txt"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// path.Match's "*" doesn't cross "/", so dir/c.txt is not included.
+	want := []string{"a.txt"}
+	if len(got) != len(want) || got[0] != want[0] {
+		t.Fatalf("filterEntries() = %v, want %v", got, want)
+	}
+}
+
+func TestFilterEntriesExcludeWins(t *testing.T) {
+	rels := []string{"a.txt", "a.tmp"}
+	got, err := filterEntries(rels, CopyTreeOptions{Include: []string{"a.*"}, Exclude: []string{"*.tmp"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0] != "a.txt" {
+		t.Fatalf("filterEntries() = %v, want [a.txt]", got)
+	}
+}
+
+func TestFilterEntriesBadPattern(t *testing.T) {
+	if _, err := filterEntries([]string{"a.txt"}, CopyTreeOptions{Include: []string{"["}}); err == nil {
+		t.Fatal("expected error for malformed include pattern, got nil")
+	}
+}
+
+func TestJoinLocationLocal(t *testing.T) {
+	base := Location{Local: true, Path: "/root/data"}
+	got := joinLocation(base, "sub/file.txt")
+	if !got.Local || got.Path == "" {
+		t.Fatalf("joinLocation() = %+v, want a local path under %q", got, base.Path)
+	}
+}
+
+func TestJoinLocationRemote(t *testing.T) {
+	base := Location{Account: "acct", Container: "container", Blob: "prefix"}
+	got := joinLocation(base, "sub/file.txt")
+	want := Location{Account: "acct", Container: "container", Blob: "prefix/sub/file.txt"}
+	if got != want {
+		t.Fatalf("joinLocation() = %+v, want %+v", got, want)
+	}
+}
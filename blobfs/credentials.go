@@ -0,0 +1,115 @@
+package blobfs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// credentialsFile mirrors the credentials.json accepted by the quick-start
+// sample. AuthMode selects which AuthProvider ResolveAuthProvider builds;
+// it defaults to "sharedkey" when empty, so existing credentials.json files
+// keep working unchanged.
+type credentialsFile struct {
+	AuthMode string `json:"auth_mode"`
+
+	AzureStorageAccountName string `json:"azure_storage_account_name"`
+	AzureStorageAccountKey  string `json:"azure_storage_access_key"`
+
+	SASURL string `json:"sas_url"`
+
+	TenantID           string `json:"tenant_id"`
+	ClientID           string `json:"client_id"`
+	ClientSecret       string `json:"client_secret"`
+	UseManagedIdentity bool   `json:"use_managed_identity"`
+	UseAzureCLI        bool   `json:"use_azure_cli"`
+}
+
+// ResolveAuthProvider figures out how to authenticate against Azure Storage
+// and returns a ready-to-use AuthProvider, trying, in order:
+//
+//  1. a credentials.json file in the current directory, whose auth_mode
+//     field ("sharedkey", "sas", or "aad") picks the provider
+//  2. the AZURE_STORAGE_ACCOUNT / AZURE_STORAGE_ACCESS_KEY environment
+//     variables (shared key)
+//  3. the AZURE_STORAGE_SAS_URL environment variable (SAS token)
+func ResolveAuthProvider() (AuthProvider, error) {
+	if creds, err := credentialsFromFile("credentials.json"); err == nil {
+		return authProviderFromConfig(creds)
+	}
+
+	if account, key := os.Getenv("AZURE_STORAGE_ACCOUNT"), os.Getenv("AZURE_STORAGE_ACCESS_KEY"); account != "" && key != "" {
+		return SharedKeyAuth{AccountName: account, AccountKey: key}, nil
+	}
+
+	if sasURL := os.Getenv("AZURE_STORAGE_SAS_URL"); sasURL != "" {
+		u, err := url.Parse(sasURL)
+		if err != nil {
+			return nil, fmt.Errorf("blobfs: invalid AZURE_STORAGE_SAS_URL: %w", err)
+		}
+		return SASAuth{AccountName: accountFromHost(u.Host), Query: u.RawQuery}, nil
+	}
+
+	return nil, fmt.Errorf("blobfs: no credentials found; provide credentials.json, AZURE_STORAGE_ACCOUNT/AZURE_STORAGE_ACCESS_KEY, or AZURE_STORAGE_SAS_URL")
+}
+
+func authProviderFromConfig(creds credentialsFile) (AuthProvider, error) {
+	switch creds.AuthMode {
+	case "", "sharedkey":
+		if creds.AzureStorageAccountName == "" || creds.AzureStorageAccountKey == "" {
+			return nil, fmt.Errorf("blobfs: credentials.json is missing account name or key")
+		}
+		return SharedKeyAuth{AccountName: creds.AzureStorageAccountName, AccountKey: creds.AzureStorageAccountKey}, nil
+
+	case "sas":
+		u, err := url.Parse(creds.SASURL)
+		if err != nil {
+			return nil, fmt.Errorf("blobfs: credentials.json has an invalid sas_url: %w", err)
+		}
+		return SASAuth{AccountName: accountFromHost(u.Host), Query: u.RawQuery}, nil
+
+	case "aad":
+		return AADAuth{
+			AccountName:        creds.AzureStorageAccountName,
+			TenantID:           creds.TenantID,
+			ClientID:           creds.ClientID,
+			ClientSecret:       creds.ClientSecret,
+			UseManagedIdentity: creds.UseManagedIdentity,
+			UseAzureCLI:        creds.UseAzureCLI,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("blobfs: credentials.json has unknown auth_mode %q", creds.AuthMode)
+	}
+}
+
+func credentialsFromFile(path string) (credentialsFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return credentialsFile{}, err
+	}
+	defer f.Close()
+
+	byteValue, err := ioutil.ReadAll(f)
+	if err != nil {
+		return credentialsFile{}, err
+	}
+
+	var creds credentialsFile
+	if err := json.Unmarshal(byteValue, &creds); err != nil {
+		return credentialsFile{}, err
+	}
+	return creds, nil
+}
+
+// accountFromHost extracts the account name from a blob service host such as
+// "myaccount.blob.core.windows.net".
+func accountFromHost(host string) string {
+	if i := strings.IndexByte(host, '.'); i >= 0 {
+		return host[:i]
+	}
+	return host
+}
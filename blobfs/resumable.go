@@ -0,0 +1,274 @@
+package blobfs
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// Recommended chunk size bounds for WriteChunk. Azure itself only enforces
+// azblob.BlockBlobMaxStageBlockBytes; staying within 4-100 MiB keeps retries
+// cheap without fragmenting the block list too much.
+const (
+	MinChunkSize = 4 * 1024 * 1024
+	MaxChunkSize = 100 * 1024 * 1024
+)
+
+// UploadInfo is the resumable-upload state persisted to the "<id>.info"
+// sidecar blob next to the target blob.
+type UploadInfo struct {
+	ID          string   `json:"id"`
+	Container   string   `json:"container"`
+	Blob        string   `json:"blob"`
+	Size        int64    `json:"size"`
+	ContentType string   `json:"content_type"`
+	Offset      int64    `json:"offset"`
+	BlockIDs    []string `json:"block_ids"`
+	// BlockLengths holds the staged byte length of each entry in BlockIDs,
+	// since chunks aren't all the same size (WriteChunk allows up to
+	// MaxChunkSize). ResumeUpload sums these instead of assuming a uniform
+	// chunk size.
+	BlockLengths []int64 `json:"block_lengths"`
+}
+
+// ResumableUploader implements a tus-style resumable upload on top of block
+// blobs: each chunk is staged with a deterministic block ID, progress is
+// recorded in an "<id>.info" sidecar blob after every successful stage, and
+// CommitBlockList is only called once the full byte range has been staged.
+type ResumableUploader struct {
+	client   *Client
+	info     UploadInfo
+	checksum ChecksumMode
+	hash     hash.Hash // accumulates the whole blob when checksum != ChecksumNone
+}
+
+func infoBlobName(id string) string {
+	return id + ".info"
+}
+
+// blockID deterministically derives the base64 block ID for chunk sequence
+// seq, so that retries of the same chunk restage the same block instead of
+// growing the uncommitted block list.
+func blockID(seq int) string {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(seq))
+	return base64.StdEncoding.EncodeToString(buf[:])
+}
+
+// NewResumableUploader returns an uploader for the given container/blob,
+// identified by id. Call Create to start a new upload, or ResumeUpload to
+// pick an existing one back up.
+func NewResumableUploader(client *Client, container, blob, id string) *ResumableUploader {
+	return &ResumableUploader{
+		client: client,
+		info:   UploadInfo{ID: id, Container: container, Blob: blob},
+	}
+}
+
+// Create starts a new resumable upload of size bytes and writes the initial
+// "<id>.info" sidecar blob.
+func (u *ResumableUploader) Create(ctx context.Context, size int64, contentType string) error {
+	u.info.Size = size
+	u.info.ContentType = contentType
+	u.info.Offset = 0
+	u.info.BlockIDs = nil
+	u.info.BlockLengths = nil
+	return u.saveInfo(ctx)
+}
+
+// EnableChecksum turns on integrity verification for this upload: each
+// staged block's MD5 is validated by Azure (mode ChecksumMD5), and the
+// whole-blob checksum is recorded on FinishUpload so DownloadVerified can
+// check it later. It must be called before the first WriteChunk; checksums
+// aren't reconstructed across a resume.
+func (u *ResumableUploader) EnableChecksum(mode ChecksumMode) {
+	u.checksum = mode
+	u.hash = newChecksumHash(mode)
+}
+
+// ResumeUpload reconstructs a ResumableUploader from its "<id>.info" sidecar
+// blob, reconciling against the blocks Azure actually has on file via
+// GetBlockList(BlockListUncommitted): from the first block the sidecar lists
+// but Azure lacks, the rest of the sidecar's list is dropped. A block that a
+// previous WriteChunk staged before crashing ahead of the sidecar update is
+// not in the sidecar; the next WriteChunk restages it under the same
+// deterministic block ID.
+func ResumeUpload(ctx context.Context, client *Client, container, blob, id string) (*ResumableUploader, error) {
+	u := NewResumableUploader(client, container, blob, id)
+
+	infoBlobURL := client.blockBlobURL(container, infoBlobName(id))
+	resp, err := infoBlobURL.Download(ctx, 0, azblob.CountToEnd, azblob.BlobAccessConditions{}, false)
+	if err != nil {
+		return nil, fmt.Errorf("blobfs: resume %s: download info blob: %w", id, err)
+	}
+	body := resp.Body(azblob.RetryReaderOptions{MaxRetryRequests: 20})
+	defer body.Close()
+	raw, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("blobfs: resume %s: read info blob: %w", id, err)
+	}
+	if err := json.Unmarshal(raw, &u.info); err != nil {
+		return nil, fmt.Errorf("blobfs: resume %s: decode info blob: %w", id, err)
+	}
+
+	// The target blob doesn't exist yet if the process crashed after Create
+	// wrote the sidecar but before the first WriteChunk staged anything;
+	// that's not a failure to resume from, just zero blocks staged so far.
+	targetBlobURL := client.blockBlobURL(container, blob)
+	blockList, err := targetBlobURL.GetBlockList(ctx, azblob.BlockListUncommitted, azblob.LeaseAccessConditions{})
+	staged := map[string]bool{}
+	if err != nil {
+		if serr, ok := err.(azblob.StorageError); ok && serr.ServiceCode() == azblob.ServiceCodeBlobNotFound {
+			err = nil
+		} else {
+			return nil, fmt.Errorf("blobfs: resume %s: get block list: %w", id, err)
+		}
+	} else {
+		for _, b := range blockList.UncommittedBlocks {
+			staged[b.Name] = true
+		}
+	}
+
+	u.info = reconcileBlocks(u.info, staged)
+	return u, nil
+}
+
+// reconcileBlocks trims info's block list down to the prefix Azure confirms
+// is actually staged, and recomputes Offset from the confirmed blocks'
+// recorded lengths. Blocks aren't all the same size (WriteChunk allows up to
+// MaxChunkSize), so the offset is summed from BlockLengths rather than
+// assumed from a uniform chunk size. It trusts the sidecar's ordering, but
+// stops at the first block missing from staged, since a resumed upload must
+// restage contiguously from the first gap.
+func reconcileBlocks(info UploadInfo, staged map[string]bool) UploadInfo {
+	confirmedIDs := info.BlockIDs[:0:0]
+	confirmedLengths := info.BlockLengths[:0:0]
+	var offset int64
+	for i, id := range info.BlockIDs {
+		if !staged[id] {
+			break
+		}
+		confirmedIDs = append(confirmedIDs, id)
+		confirmedLengths = append(confirmedLengths, info.BlockLengths[i])
+		offset += info.BlockLengths[i]
+	}
+	info.BlockIDs = confirmedIDs
+	info.BlockLengths = confirmedLengths
+	if offset < info.Offset {
+		info.Offset = offset
+	}
+	return info
+}
+
+// WriteChunk stages the bytes read from r as the next block, starting at
+// offset, and updates the sidecar info blob once the block is staged. offset
+// must equal the uploader's current offset; chunks must be written in order.
+func (u *ResumableUploader) WriteChunk(ctx context.Context, offset int64, r io.Reader) error {
+	if offset != u.info.Offset {
+		return fmt.Errorf("blobfs: WriteChunk %s: expected offset %d, got %d", u.info.ID, u.info.Offset, offset)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(r, MaxChunkSize+1))
+	if err != nil {
+		return fmt.Errorf("blobfs: WriteChunk %s: read chunk: %w", u.info.ID, err)
+	}
+	if int64(len(data)) > MaxChunkSize {
+		return fmt.Errorf("blobfs: WriteChunk %s: chunk exceeds MaxChunkSize (%d bytes); split it into smaller WriteChunk calls", u.info.ID, MaxChunkSize)
+	}
+	if len(u.info.BlockIDs) >= azblob.BlockBlobMaxBlocks {
+		return fmt.Errorf("blobfs: WriteChunk %s: upload already has BlockBlobMaxBlocks (%d) staged blocks", u.info.ID, azblob.BlockBlobMaxBlocks)
+	}
+
+	seq := len(u.info.BlockIDs)
+	id := blockID(seq)
+
+	var transactionalMD5 []byte
+	if u.checksum == ChecksumMD5 {
+		sum := md5.Sum(data)
+		transactionalMD5 = sum[:]
+	}
+
+	blobURL := u.client.blockBlobURL(u.info.Container, u.info.Blob)
+	if _, err := blobURL.StageBlock(ctx, id, bytes.NewReader(data), azblob.LeaseAccessConditions{}, transactionalMD5); err != nil {
+		return fmt.Errorf("blobfs: WriteChunk %s: stage block %d: %w", u.info.ID, seq, err)
+	}
+
+	if u.hash != nil {
+		u.hash.Write(data)
+	}
+
+	u.info.BlockIDs = append(u.info.BlockIDs, id)
+	u.info.BlockLengths = append(u.info.BlockLengths, int64(len(data)))
+	u.info.Offset += int64(len(data))
+	return u.saveInfo(ctx)
+}
+
+// GetInfo returns the uploader's current state.
+func (u *ResumableUploader) GetInfo() UploadInfo {
+	return u.info
+}
+
+// FinishUpload commits the staged blocks into the final blob once the full
+// byte range has been accounted for, and removes the sidecar info blob.
+func (u *ResumableUploader) FinishUpload(ctx context.Context) error {
+	if u.info.Offset != u.info.Size {
+		return fmt.Errorf("blobfs: FinishUpload %s: only %d of %d bytes staged", u.info.ID, u.info.Offset, u.info.Size)
+	}
+
+	headers := azblob.BlobHTTPHeaders{ContentType: u.info.ContentType}
+	metadata := azblob.Metadata{}
+	if u.hash != nil {
+		switch u.checksum {
+		case ChecksumMD5:
+			headers.ContentMD5 = u.hash.Sum(nil)
+		case ChecksumCRC64:
+			metadata[crc64MetadataKey] = base64.StdEncoding.EncodeToString(u.hash.Sum(nil))
+		}
+	}
+
+	blobURL := u.client.blockBlobURL(u.info.Container, u.info.Blob)
+	if _, err := blobURL.CommitBlockList(ctx, u.info.BlockIDs, headers, metadata, azblob.BlobAccessConditions{}); err != nil {
+		return fmt.Errorf("blobfs: FinishUpload %s: commit block list: %w", u.info.ID, err)
+	}
+
+	infoBlobURL := u.client.blockBlobURL(u.info.Container, infoBlobName(u.info.ID))
+	if _, err := infoBlobURL.Delete(ctx, azblob.DeleteSnapshotsOptionNone, azblob.BlobAccessConditions{}); err != nil {
+		return fmt.Errorf("blobfs: FinishUpload %s: delete info blob: %w", u.info.ID, err)
+	}
+	return nil
+}
+
+// Terminate abandons the upload: it deletes the sidecar info blob so the
+// upload can't be resumed. Blocks staged but never committed are garbage
+// collected by the service automatically; there is no API to reclaim them
+// early.
+func (u *ResumableUploader) Terminate(ctx context.Context) error {
+	infoBlobURL := u.client.blockBlobURL(u.info.Container, infoBlobName(u.info.ID))
+	if _, err := infoBlobURL.Delete(ctx, azblob.DeleteSnapshotsOptionNone, azblob.BlobAccessConditions{}); err != nil {
+		return fmt.Errorf("blobfs: Terminate %s: delete info blob: %w", u.info.ID, err)
+	}
+	return nil
+}
+
+func (u *ResumableUploader) saveInfo(ctx context.Context) error {
+	raw, err := json.Marshal(u.info)
+	if err != nil {
+		return fmt.Errorf("blobfs: encode info for %s: %w", u.info.ID, err)
+	}
+
+	infoBlobURL := u.client.blockBlobURL(u.info.Container, infoBlobName(u.info.ID))
+	_, err = azblob.UploadBufferToBlockBlob(ctx, raw, infoBlobURL, azblob.UploadToBlockBlobOptions{})
+	if err != nil {
+		return fmt.Errorf("blobfs: save info for %s: %w", u.info.ID, err)
+	}
+	return nil
+}
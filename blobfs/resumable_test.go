@@ -0,0 +1,57 @@
+package blobfs
+
+import "testing"
+
+func TestBlockIDDeterministic(t *testing.T) {
+	if blockID(0) != blockID(0) {
+		t.Fatal("blockID is not deterministic")
+	}
+	if blockID(0) == blockID(1) {
+		t.Fatal("blockID(0) and blockID(1) collided")
+	}
+}
+
+func TestReconcileBlocksAllStaged(t *testing.T) {
+	info := UploadInfo{
+		BlockIDs:     []string{"a", "b", "c"},
+		BlockLengths: []int64{4, 4, 2},
+		Offset:       10,
+	}
+	staged := map[string]bool{"a": true, "b": true, "c": true}
+
+	got := reconcileBlocks(info, staged)
+	if len(got.BlockIDs) != 3 || got.Offset != 10 {
+		t.Fatalf("reconcileBlocks() = %+v, want all 3 blocks confirmed at offset 10", got)
+	}
+}
+
+func TestReconcileBlocksStopsAtFirstGap(t *testing.T) {
+	info := UploadInfo{
+		BlockIDs:     []string{"a", "b", "c"},
+		BlockLengths: []int64{4, 4, 2},
+		Offset:       10,
+	}
+	// "b" never made it to the service, so "c" can't be trusted either, even
+	// though it's present - blocks must restage contiguously from the gap.
+	staged := map[string]bool{"a": true, "c": true}
+
+	got := reconcileBlocks(info, staged)
+	if len(got.BlockIDs) != 1 || got.BlockIDs[0] != "a" {
+		t.Fatalf("reconcileBlocks() BlockIDs = %v, want only [a]", got.BlockIDs)
+	}
+	if got.Offset != 4 {
+		t.Fatalf("reconcileBlocks() Offset = %d, want 4", got.Offset)
+	}
+}
+
+func TestReconcileBlocksNoneStaged(t *testing.T) {
+	info := UploadInfo{
+		BlockIDs:     []string{"a", "b"},
+		BlockLengths: []int64{4, 4},
+		Offset:       8,
+	}
+	got := reconcileBlocks(info, map[string]bool{})
+	if len(got.BlockIDs) != 0 || got.Offset != 0 {
+		t.Fatalf("reconcileBlocks() = %+v, want zero blocks and offset 0", got)
+	}
+}
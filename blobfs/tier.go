@@ -0,0 +1,70 @@
+package blobfs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// UploadOptions configures Upload's storage tier and integrity checking.
+type UploadOptions struct {
+	// Tier sets the blob's access tier once the upload commits. Leave empty
+	// (azblob.AccessTierNone) to use the account's configured default tier.
+	Tier azblob.AccessTierType
+
+	// Checksum hashes the file before upload and records the digest as
+	// BlobContentMD5 (ChecksumMD5) or blob metadata (ChecksumCRC64), so a
+	// later DownloadVerified call can confirm the blob wasn't corrupted in
+	// transit or at rest. Leave as ChecksumNone to skip this.
+	Checksum ChecksumMode
+}
+
+// UploadWithOptions uploads localPath to dst like Upload, additionally
+// recording an integrity checksum and setting the blob's access tier once
+// the upload has committed.
+func (c *Client) UploadWithOptions(ctx context.Context, localPath string, dst Location, opts UploadOptions) error {
+	if err := c.uploadFile(ctx, localPath, dst, opts); err != nil {
+		return err
+	}
+	if opts.Tier == azblob.AccessTierNone {
+		return nil
+	}
+
+	blobURL := c.blockBlobURL(dst.Container, dst.Blob)
+	if _, err := blobURL.SetTier(ctx, opts.Tier, azblob.LeaseAccessConditions{}, azblob.RehydratePriorityNone); err != nil {
+		return fmt.Errorf("blobfs: set tier %s on %s: %w", opts.Tier, dst, err)
+	}
+	return nil
+}
+
+// rehydratePollInterval is how often Rehydrate checks GetProperties while
+// waiting for an Archive -> Hot/Cool rehydration to finish.
+const rehydratePollInterval = 30 * time.Second
+
+// Rehydrate moves an archived blob back to tier (Hot or Cool) at the given
+// priority, then blocks polling GetProperties until the blob's ArchiveStatus
+// clears, signalling the rehydration has completed.
+func (c *Client) Rehydrate(ctx context.Context, loc Location, tier azblob.AccessTierType, priority azblob.RehydratePriorityType) error {
+	blobURL := c.blockBlobURL(loc.Container, loc.Blob)
+	if _, err := blobURL.SetTier(ctx, tier, azblob.LeaseAccessConditions{}, priority); err != nil {
+		return fmt.Errorf("blobfs: rehydrate %s to %s: %w", loc, tier, err)
+	}
+
+	for {
+		props, err := blobURL.GetProperties(ctx, azblob.BlobAccessConditions{})
+		if err != nil {
+			return fmt.Errorf("blobfs: rehydrate %s: poll properties: %w", loc, err)
+		}
+		if props.ArchiveStatus() == "" {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(rehydratePollInterval):
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package blobfs
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// scheme is the URI scheme used to address a blob, e.g.
+// abs://myaccount/mycontainer/path/to/blob.
+const scheme = "abs"
+
+// Location describes one side of a copy: either a path on the local
+// filesystem, or a blob inside an Azure Storage account.
+type Location struct {
+	Local bool
+	Path  string // local filesystem path, set when Local is true
+
+	Account   string
+	Container string
+	Blob      string // blob name, may contain "/" for virtual directories
+}
+
+func (l Location) String() string {
+	if l.Local {
+		return l.Path
+	}
+	return fmt.Sprintf("%s://%s/%s/%s", scheme, l.Account, l.Container, l.Blob)
+}
+
+// ParseURI resolves a src/dst argument into a Location. A URI of the form
+// abs://<account>/<container>/<path> addresses a blob; anything else is
+// treated as a path on the local filesystem.
+func ParseURI(raw string) (Location, error) {
+	if !strings.HasPrefix(raw, scheme+"://") {
+		return Location{Local: true, Path: raw}, nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Location{}, fmt.Errorf("blobfs: invalid URI %q: %w", raw, err)
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 2)
+	if u.Host == "" || len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return Location{}, fmt.Errorf("blobfs: URI %q must look like %s://<account>/<container>/<path>", raw, scheme)
+	}
+
+	return Location{Account: u.Host, Container: parts[0], Blob: parts[1]}, nil
+}
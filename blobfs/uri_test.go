@@ -0,0 +1,37 @@
+package blobfs
+
+import "testing"
+
+func TestParseURILocal(t *testing.T) {
+	for _, raw := range []string{"file.txt", "./dir/file.txt", "/abs/path", "C:\\windows\\path"} {
+		loc, err := ParseURI(raw)
+		if err != nil {
+			t.Fatalf("ParseURI(%q): unexpected error: %v", raw, err)
+		}
+		if !loc.Local || loc.Path != raw {
+			t.Errorf("ParseURI(%q) = %+v, want Local Location with Path %q", raw, loc, raw)
+		}
+	}
+}
+
+func TestParseURIRemote(t *testing.T) {
+	loc, err := ParseURI("abs://myaccount/mycontainer/path/to/blob.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Location{Account: "myaccount", Container: "mycontainer", Blob: "path/to/blob.txt"}
+	if loc != want {
+		t.Errorf("ParseURI() = %+v, want %+v", loc, want)
+	}
+	if got := loc.String(); got != "abs://myaccount/mycontainer/path/to/blob.txt" {
+		t.Errorf("Location.String() = %q, want round-trip of the original URI", got)
+	}
+}
+
+func TestParseURIRemoteInvalid(t *testing.T) {
+	for _, raw := range []string{"abs://", "abs://account", "abs://account/container", "abs://account//blob", "abs:///container/blob"} {
+		if _, err := ParseURI(raw); err == nil {
+			t.Errorf("ParseURI(%q): expected error, got nil", raw)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Command blobcp copies a file or blob to another location, where either
+// side may be a local path or an abs://<account>/<container>/<path> URI. It
+// can also list containers and blobs filtered by a substring match, and copy
+// whole trees recursively.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/IdiotLeon/QuickStartGoingWithAzureStorageBlob/blobfs"
+)
+
+// globList collects repeated -include/-exclude flags into a slice.
+type globList []string
+
+func (g *globList) String() string     { return strings.Join(*g, ",") }
+func (g *globList) Set(v string) error { *g = append(*g, v); return nil }
+
+func main() {
+	src := flag.String("src", "", "source: a local path or abs://<account>/<container>/<path>")
+	dst := flag.String("dst", "", "destination: a local path or abs://<account>/<container>/<path>")
+	recursive := flag.Bool("recursive", false, "copy src as a tree instead of a single file/blob")
+	concurrency := flag.Int("concurrency", 1, "number of concurrent copies when -recursive is set")
+	dryRun := flag.Bool("dry-run", false, "print the planned copies instead of performing them, with -recursive")
+
+	list := flag.Bool("list", false, "list containers (and their blobs) instead of copying")
+	containerFilter := flag.String("container", "", "substring filter on container name, with -list")
+	blobFilter := flag.String("blob", "", "substring filter on blob name, with -list")
+
+	tier := flag.String("tier", "", "access tier to set on -dst after upload: hot, cool, or archive")
+	rehydrate := flag.String("rehydrate", "", "rehydrate the archived blob at -src, formatted <tier>:<priority>, e.g. hot:high")
+
+	var include, exclude globList
+	flag.Var(&include, "include", "glob pattern to include, with -recursive (repeatable)")
+	flag.Var(&exclude, "exclude", "glob pattern to exclude, with -recursive (repeatable)")
+
+	flag.Parse()
+
+	client, err := blobfs.NewClientFromEnvironment()
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if *list {
+		if err := listContainers(ctx, client, *containerFilter, *blobFilter); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *rehydrate != "" {
+		if *src == "" {
+			log.Fatal("blobcp: -src is required with -rehydrate")
+		}
+		t, priority, err := parseRehydrate(*rehydrate)
+		if err != nil {
+			log.Fatal(err)
+		}
+		loc, err := blobfs.ParseURI(*src)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := client.Rehydrate(ctx, loc, t, priority); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *src == "" || *dst == "" {
+		log.Fatal("blobcp: both -src and -dst are required")
+	}
+
+	if *tier != "" {
+		if *recursive {
+			log.Fatal("blobcp: -tier is not supported with -recursive")
+		}
+		t, err := parseTier(*tier)
+		if err != nil {
+			log.Fatal(err)
+		}
+		dstLoc, err := blobfs.ParseURI(*dst)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if dstLoc.Local {
+			log.Fatal("blobcp: -tier requires -dst to be an abs://<account>/<container>/<path> blob URI")
+		}
+		if err := client.UploadWithOptions(ctx, *src, dstLoc, blobfs.UploadOptions{Tier: t}); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *recursive {
+		opts := blobfs.CopyTreeOptions{
+			Include:     include,
+			Exclude:     exclude,
+			Concurrency: *concurrency,
+			DryRun:      *dryRun,
+			Progress: func(done, total int64, name string) {
+				fmt.Printf("[%d/%d] %s\n", done, total, name)
+			},
+		}
+		if err := client.CopyTree(ctx, *src, *dst, opts); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := client.Copy(ctx, *src, *dst); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func parseTier(s string) (azblob.AccessTierType, error) {
+	switch strings.ToLower(s) {
+	case "hot":
+		return azblob.AccessTierHot, nil
+	case "cool":
+		return azblob.AccessTierCool, nil
+	case "archive":
+		return azblob.AccessTierArchive, nil
+	default:
+		return azblob.AccessTierNone, fmt.Errorf("blobcp: unknown -tier %q (want hot, cool, or archive)", s)
+	}
+}
+
+func parseRehydrate(s string) (azblob.AccessTierType, azblob.RehydratePriorityType, error) {
+	tierName, priorityName, ok := strings.Cut(s, ":")
+	if !ok {
+		return azblob.AccessTierNone, "", fmt.Errorf("blobcp: -rehydrate must look like <tier>:<priority>, e.g. hot:high")
+	}
+
+	tier, err := parseTier(tierName)
+	if err != nil {
+		return azblob.AccessTierNone, "", err
+	}
+
+	switch strings.ToLower(priorityName) {
+	case "high":
+		return tier, azblob.RehydratePriorityHigh, nil
+	case "standard":
+		return tier, azblob.RehydratePriorityStandard, nil
+	default:
+		return azblob.AccessTierNone, "", fmt.Errorf("blobcp: unknown rehydrate priority %q (want high or standard)", priorityName)
+	}
+}
+
+func listContainers(ctx context.Context, client *blobfs.Client, containerFilter, blobFilter string) error {
+	containers, err := client.ListContainers(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, container := range containers {
+		if containerFilter != "" && !strings.Contains(container, containerFilter) {
+			continue
+		}
+
+		fmt.Println(container)
+		blobs, err := client.List(ctx, container, "")
+		if err != nil {
+			return err
+		}
+		for _, blob := range blobs {
+			if blobFilter != "" && !strings.Contains(blob, blobFilter) {
+				continue
+			}
+			fmt.Printf("  %s\n", blob)
+		}
+	}
+	return nil
+}